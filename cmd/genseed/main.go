@@ -0,0 +1,53 @@
+// Command genseed generates a grammar-driven seed corpus for the
+// FuzzAnyParsing and FuzzCanonicalParsing fuzz targets by enumerating
+// domain, path, tag, and digest shapes from the distribution/reference
+// grammar and round-tripping each through reference_oracle.Parse and
+// reference_oracle.ParseCanonical. The same seed set is written to both
+// targets' corpus directories, since every seed is already checked
+// against both parse modes (see oracle.WriteCorpus).
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	oracle "github.com/skalt/container_image_dist_ref/pkg/reference_oracle"
+)
+
+func main() {
+	dir := flag.String(
+		"dir",
+		"internal/reference_oracle/testdata/fuzz/FuzzAnyParsing",
+		"directory to write the seed corpus to, in Go's native fuzz-corpus layout",
+	)
+	canonicalDir := flag.String(
+		"canonical-dir",
+		"internal/fuzz_canonical/testdata/fuzz/FuzzCanonicalParsing",
+		"directory to write the same seed corpus to for FuzzCanonicalParsing",
+	)
+	flag.Parse()
+
+	seeds := oracle.NewCorpusGenerator().Generate()
+
+	missing, err := oracle.WriteCorpus(*dir, seeds)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "genseed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("genseed: wrote %d seeds to %s\n", len(seeds), *dir)
+
+	if _, err := oracle.WriteCorpus(*canonicalDir, seeds); err != nil {
+		fmt.Fprintf(os.Stderr, "genseed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("genseed: wrote %d seeds to %s\n", len(seeds), *canonicalDir)
+
+	if len(missing) > 0 {
+		fmt.Fprintln(os.Stderr, "genseed: no seed exercised these Go-library error classes (GoErrorClasses):")
+		for _, class := range missing {
+			fmt.Fprintf(os.Stderr, "  - %v\n", class)
+		}
+		os.Exit(1)
+	}
+}
@@ -1,86 +1,92 @@
 package fuzz_canonical
 
 import (
-	"io/fs"
 	"os"
-	"os/exec"
+	"regexp"
 	"strings"
+	"sync"
 	"testing"
 	"unicode/utf8"
 
 	"github.com/skalt/container_image_dist_ref/internal/reference_oracle"
+	oracle "github.com/skalt/container_image_dist_ref/pkg/reference_oracle"
 )
 
 const canonicalRefParserPath = "../../target/debug/examples/parse_canonical"
 
+var digestPat = regexp.MustCompile(`[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*[:][[:xdigit:]]{32,}`)
+
+var (
+	runnerOnce sync.Once
+	runner     *reference_oracle.Runner
+	runnerErr  error
+)
+
+func getRunner(t *testing.T) *reference_oracle.Runner {
+	runnerOnce.Do(func() {
+		runner, runnerErr = reference_oracle.NewRunner(canonicalRefParserPath)
+	})
+	if runnerErr != nil {
+		t.Fatalf("unable to start %s: %v", canonicalRefParserPath, runnerErr)
+	}
+	return runner
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if runner != nil {
+		_ = runner.Close()
+	}
+	os.Exit(code)
+}
+
 func canonicalHarness(t *testing.T, input string) {
 	// skip the test if the input is invalid utf8
 	if !utf8.ValidString(input) {
 		return
 	}
-	input = strings.TrimRight(input, "\r\n")
-	oracle := reference_oracle.ParseCanonical(input)
+	expected := reference_oracle.ParseCanonical(input)
 	t.Logf("input: \"%s\"", input)
 
-	cmd := exec.Cmd{Path: canonicalRefParserPath, Stdin: strings.NewReader(input + "\n")}
-	resultBytes, err := cmd.Output()
-	if err != nil { // rust lib errored
-		if e, ok := err.(*exec.ExitError); ok {
-			switch e.ExitCode() {
-			case 0:
-				t.Fatal("unreachable")
-			case 1:
-				// normal rust lib error
-				result := reference_oracle.ParseTsv(string(resultBytes))
-				if oracle.Err == "" { // distribution/reference parsed successfully
-					// the rust lib differs from the go lib by constraining IPv6 addresses
-					if reference_oracle.Ipv6ExpectedFailure(result.Err) {
-						return
-					}
-					t.Errorf("unexpected error:\n%s\n\n%s", result.Err, oracle.Pretty())
-					return
-				} else {
-					// ok: distribution/reference errored just like the rust lib did
-					return
-				}
-			default:
-				// the rust lib panicked
-				t.Error(string(e.Stderr))
-				break
-			}
-		} else if _, ok := err.(*fs.PathError); ok {
-			cwd, _ := os.Getwd()
-			t.Fatalf("unable to find %s\nwrong cwd: %s", canonicalRefParserPath, cwd)
-
-		} else {
-			// unexpected error
-			t.Fatal(err)
-		}
-	} else {
-		// the rust lib parsed successfully
-		result := reference_oracle.ParseTsv(string(resultBytes))
-		diff, same := oracle.Diff(&result)
-		if oracle.Err != "" { // distribution/reference errored
-			switch result.DigestAlgo {
-			case "sha256":
-			case "sha512":
-				// unexpected error, distribution/reference supports support these
-				// check the pattern:
-				if reference_oracle.DigestPat.Match([]byte(result.DigestAlgo + ":" + result.DigestEncoded)) {
-					t.Log("matched?")
-				}
-				t.Errorf("unexpected error in registered algorithm:\n%s", diff)
-				return
-			default:
-				// expected error: distribution/reference can't handle non-registered algorithms
+	actual, err := getRunner(t).Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual.Err != "" { // rust lib errored
+		if expected.Err == "" { // distribution/reference parsed successfully
+			// the rust lib differs from the go lib by constraining IPv6 addresses
+			if oracle.IsIPv6Error(oracle.ClassifyError(actual.Err)) {
 				return
 			}
-		} else { // distribution/reference parsed successfully
-			if !same {
-				t.Errorf("diff:\n%s", diff)
+			t.Errorf("unexpected error:\n%s\n\n%s", actual.Err, reference_oracle.Pretty(expected))
+			return
+		}
+		// ok: distribution/reference errored just like the rust lib did
+		return
+	}
+	// the rust lib parsed successfully
+	diff, same := reference_oracle.Diff(expected, actual)
+	if expected.Err != "" { // distribution/reference errored
+		actualAlgo, actualEncoded, _ := actual.Digest()
+		switch actualAlgo {
+		case "sha256":
+		case "sha512":
+			// unexpected error, distribution/reference supports support these
+			// check the pattern:
+			if digestPat.MatchString(actualAlgo + ":" + actualEncoded) {
+				t.Log("matched?")
 			}
+			t.Errorf("unexpected error in registered algorithm:\n%s", diff)
+			return
+		default:
+			// expected error: distribution/reference can't handle non-registered algorithms
 			return
 		}
+	} else { // distribution/reference parsed successfully
+		if !same {
+			t.Errorf("diff:\n%s", diff)
+		}
+		return
 	}
 }
 
@@ -1,6 +1,7 @@
-package main
+package reference_oracle
 
 import (
+	"os/exec"
 	"strings"
 	"testing"
 
@@ -15,7 +16,7 @@ import (
 //go:embed inputs.txt
 var rawInputs string
 
-func BenchmarkOracleEntireTestSuite(b *testing.B) {
+func filteredInputs() []string {
 	var inputs = strings.Split(rawInputs, "\n")
 	var filtered = make([]string, 0, len(inputs))
 	for _, ref := range inputs {
@@ -23,6 +24,11 @@ func BenchmarkOracleEntireTestSuite(b *testing.B) {
 			filtered = append(filtered, ref)
 		}
 	}
+	return filtered
+}
+
+func BenchmarkOracleEntireTestSuite(b *testing.B) {
+	filtered := filteredInputs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		for _, ref := range filtered {
@@ -32,16 +38,42 @@ func BenchmarkOracleEntireTestSuite(b *testing.B) {
 }
 
 func BenchmarkJustIteration(b *testing.B) {
-	var inputs = strings.Split(rawInputs, "\n")
-	var filtered = make([]string, 0, len(inputs))
-	for _, ref := range inputs {
-		if ref != "" {
-			filtered = append(filtered, ref)
+	filtered := filteredInputs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, _ = range filtered {
 		}
 	}
+}
+
+// BenchmarkSubprocessPerInput models the original fuzz-harness behavior
+// of forking a fresh `parse_stdin` process for every input.
+func BenchmarkSubprocessPerInput(b *testing.B) {
+	filtered := filteredInputs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		for _, _ = range filtered {
+		for _, ref := range filtered {
+			cmd := exec.Cmd{Path: binPath, Stdin: strings.NewReader(ref + "\n")}
+			_, _ = cmd.Output()
+		}
+	}
+}
+
+// BenchmarkRunnerPerInput models the persistent-subprocess Runner: one
+// `parse_stdin` process serves every input in the suite.
+func BenchmarkRunnerPerInput(b *testing.B) {
+	filtered := filteredInputs()
+	runner, err := NewRunner(binPath)
+	if err != nil {
+		b.Fatalf("unable to start %s: %v", binPath, err)
+	}
+	defer runner.Close()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, ref := range filtered {
+			if _, err := runner.Parse(ref); err != nil {
+				b.Fatalf("parse failed: %v", err)
+			}
 		}
 	}
 }
@@ -0,0 +1,196 @@
+package reference_oracle
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+
+	oracle "github.com/skalt/container_image_dist_ref/pkg/reference_oracle"
+)
+
+// ErrRunnerClosed is returned by Parse once Close has been called.
+var ErrRunnerClosed = errors.New("reference_oracle: runner closed")
+
+// escape encodes embedded tabs/newlines/carriage-returns so a reference
+// survives the line-delimited protocol; it's oracle.UnescapeField's
+// inverse.
+//
+// This is a new wire-protocol requirement this package's persistent
+// subprocess introduces: the one-process-per-input harness it replaces
+// wrote inputs to the oracle binary's stdin raw, since the whole stream
+// was always exactly one reference. The oracle binary must decode this
+// same escaping on read for multi-request framing to work at all (an
+// unescaped input containing a literal tab/newline/backslash would
+// otherwise desync request/response framing, or be silently
+// reinterpreted as a different string). This repository doesn't carry
+// the oracle binary's source, so that decoding can't be verified from
+// here -- confirm it against the binary this Runner is pointed at
+// before relying on this protocol in production.
+func escape(s string) string {
+	return oracle.EscapeField(s)
+}
+
+type parseRequest struct {
+	input    string
+	response chan<- parseResponse
+}
+
+type parseResponse struct {
+	result oracle.Row
+	err    error
+}
+
+// Runner keeps a single instance of the Rust reference-parsing binary
+// running as a subprocess, feeding it one escaped reference per line on
+// stdin and reading one TSV response per line from stdout. Spawning a
+// fresh process per input dominates fuzzing throughput; Runner amortizes
+// that cost across the whole run.
+//
+// Parse is safe to call concurrently: calls are serialized onto the
+// subprocess through an internal request queue. If the subprocess
+// crashes mid-request, Runner restarts it and resends the in-flight
+// request once; Parse reports an error only if the restart or that
+// retry fails too.
+type Runner struct {
+	binPath string
+
+	mu     sync.Mutex // guards cmd/stdin/stdout across restarts
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+
+	// stateMu guards closed and, together with it, makes "check closed,
+	// then send on requests" atomic with "mark closed, then close
+	// requests" in Close: Parse holds a read lock across its send, and
+	// Close takes the write lock before closing requests, so a send can
+	// never race a close of the same channel (which would panic).
+	stateMu sync.RWMutex
+	closed  bool
+
+	requests  chan parseRequest
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewRunner starts the oracle binary at binPath and returns a Runner
+// ready to serve Parse calls.
+func NewRunner(binPath string) (*Runner, error) {
+	r := &Runner{
+		binPath:  binPath,
+		requests: make(chan parseRequest),
+		done:     make(chan struct{}),
+	}
+	if err := r.start(); err != nil {
+		return nil, err
+	}
+	go r.loop()
+	return r, nil
+}
+
+func (r *Runner) start() error {
+	cmd := exec.Command(r.binPath)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	r.cmd = cmd
+	r.stdin = stdin
+	r.stdout = bufio.NewReader(stdout)
+	return nil
+}
+
+// restart waits for the old process to exit and starts a fresh one. The
+// caller must hold r.mu.
+func (r *Runner) restart() error {
+	if r.cmd != nil {
+		_ = r.stdin.Close()
+		_ = r.cmd.Wait()
+	}
+	return r.start()
+}
+
+func (r *Runner) loop() {
+	for req := range r.requests {
+		result, err := r.parseOne(req.input)
+		req.response <- parseResponse{result: result, err: err}
+	}
+	close(r.done)
+}
+
+// writeAndRead sends one escaped request line to the subprocess and
+// reads back its one-line TSV response. The caller must hold r.mu.
+func (r *Runner) writeAndRead(input string) (string, error) {
+	if _, err := io.WriteString(r.stdin, escape(input)+"\n"); err != nil {
+		return "", err
+	}
+	return r.stdout.ReadString('\n')
+}
+
+func (r *Runner) parseOne(input string) (oracle.Row, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	line, err := r.writeAndRead(input)
+	if err != nil {
+		// The subprocess may have crashed either before reading the
+		// request (write failure) or after reading it but before
+		// responding (read failure, the normal shape of a mid-request
+		// crash). Either way, restart and resend the whole request once
+		// so the crash is transparent to the caller.
+		if restartErr := r.restart(); restartErr != nil {
+			return oracle.Row{}, fmt.Errorf("reference_oracle: subprocess crashed and failed to restart: %w", restartErr)
+		}
+		line, err = r.writeAndRead(input)
+		if err != nil {
+			return oracle.Row{}, fmt.Errorf("reference_oracle: subprocess crashed again on retry: %w", err)
+		}
+	}
+	return oracle.UnmarshalTSV(line)
+}
+
+// Parse sends input to the oracle subprocess and returns the Row it
+// reported. Parse is safe to call from multiple goroutines, including
+// concurrently with Close.
+func (r *Runner) Parse(input string) (oracle.Row, error) {
+	r.stateMu.RLock()
+	if r.closed {
+		r.stateMu.RUnlock()
+		return oracle.Row{}, ErrRunnerClosed
+	}
+	response := make(chan parseResponse, 1)
+	r.requests <- parseRequest{input: input, response: response}
+	r.stateMu.RUnlock()
+	res := <-response
+	return res.result, res.err
+}
+
+// Close drains any in-flight request and shuts the subprocess down. It
+// is safe to call Close more than once, and concurrently with Parse.
+func (r *Runner) Close() error {
+	var err error
+	r.closeOnce.Do(func() {
+		r.stateMu.Lock()
+		r.closed = true
+		close(r.requests)
+		r.stateMu.Unlock()
+		<-r.done
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.stdin != nil {
+			_ = r.stdin.Close()
+		}
+		if r.cmd != nil {
+			err = r.cmd.Wait()
+		}
+	})
+	return err
+}
@@ -0,0 +1,146 @@
+package reference_oracle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// writeStandIn writes a shell script running body, standing in for the
+// Rust oracle binary so Runner's protocol handling can be exercised
+// without it. The bodies below reply with a fixed 8-field TSV line (just
+// enough to satisfy UnmarshalTSV) for the lines they read on stdin.
+func writeStandIn(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stand-in.sh")
+	script := "#!/bin/sh\n" + body
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+const echoLineForever = `while IFS= read -r line; do
+  printf 'x\tn\td\tp\tt\ta\te\t\n'
+done
+`
+
+func TestRunnerParseRoundTrips(t *testing.T) {
+	r, err := NewRunner(writeStandIn(t, echoLineForever))
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+	defer r.Close()
+
+	row, err := r.Parse("example.com/foo")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if row.Name() != "n" || row.Domain() != "d" {
+		t.Errorf("Parse returned %+v, want the stand-in's fixed row", row)
+	}
+}
+
+// TestRunnerParseConcurrentWithClose hammers Parse from many goroutines
+// while Close runs concurrently. It's a regression test for the
+// close(r.requests)/send race fixed in a prior commit: every Parse call
+// must either complete or see ErrRunnerClosed, never panic on a send to
+// a closed channel. Run with -race.
+func TestRunnerParseConcurrentWithClose(t *testing.T) {
+	r, err := NewRunner(writeStandIn(t, echoLineForever))
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+
+	const goroutines = 50
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				_, err := r.Parse("example.com/foo")
+				if err != nil && err != ErrRunnerClosed {
+					t.Errorf("Parse: unexpected error: %v", err)
+				}
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := r.Close(); err != nil {
+			t.Errorf("Close: %v", err)
+		}
+	}()
+
+	wg.Wait()
+
+	if _, err := r.Parse("example.com/foo"); err != ErrRunnerClosed {
+		t.Errorf("Parse after Close = %v, want ErrRunnerClosed", err)
+	}
+}
+
+func TestRunnerCloseIsIdempotent(t *testing.T) {
+	r, err := NewRunner(writeStandIn(t, echoLineForever))
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("first Close: %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+}
+
+// TestRunnerRestartsOnCrash exercises the restart path: a stand-in that
+// exits after every line forces Runner to relaunch the subprocess
+// between requests, the same way it would after the real oracle binary
+// crashes mid-fuzz.
+func TestRunnerRestartsOnCrash(t *testing.T) {
+	r, err := NewRunner(writeStandIn(t, `read -r line
+printf 'x\tn\td\tp\tt\ta\te\t\n'
+`))
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+	defer r.Close()
+
+	for i := 0; i < 5; i++ {
+		if _, err := r.Parse("example.com/foo"); err != nil {
+			t.Fatalf("Parse #%d: %v", i, err)
+		}
+	}
+}
+
+// TestRunnerRetriesAfterReadFailure exercises the read-failure branch of
+// parseOne specifically: the stand-in accepts and reads the request
+// (the write succeeds) but crashes without responding on its first
+// invocation only, the shape of a subprocess dying "mid-request" after
+// consuming input. Parse must transparently retry against the
+// restarted process rather than surfacing the crash as an error.
+func TestRunnerRetriesAfterReadFailure(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "count")
+	script := fmt.Sprintf(`n=$(cat %q 2>/dev/null || echo 0)
+n=$((n+1))
+echo "$n" > %q
+read -r line
+if [ "$n" -eq 1 ]; then
+  exit 1
+fi
+printf 'x\tn\td\tp\tt\ta\te\t\n'
+`, counter, counter)
+	r, err := NewRunner(writeStandIn(t, script))
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+	defer r.Close()
+
+	if _, err := r.Parse("example.com/foo"); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package reference_oracle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CorpusGenerator enumerates representative shapes of the
+// distribution/reference grammar and cross-products them into
+// candidate reference strings, for seeding this project's fuzz
+// corpora.
+type CorpusGenerator struct {
+	// Domains holds host shapes: bare hostname, hostname:port, IPv4,
+	// IPv6, IPv6:port, localhost, and an uppercase-bearing host.
+	Domains []string
+	// Paths holds path shapes: single- and multi-component, each
+	// separator (-, _, __, .), and a near-max-length component.
+	Paths []string
+	// Tags holds tag shapes: empty (no tag), a typical tag, a
+	// max-length (128-byte) tag, and an invalid boundary character.
+	Tags []string
+	// Digests holds digest shapes: registered (sha256/sha512),
+	// unregistered, and malformed-length or uppercase-hex encodings.
+	Digests []string
+}
+
+// NewCorpusGenerator returns a CorpusGenerator seeded with
+// representative shapes from each grammar production.
+func NewCorpusGenerator() *CorpusGenerator {
+	return &CorpusGenerator{
+		Domains: []string{
+			"example.com",
+			"example.com:5000",
+			"127.0.0.1",
+			"127.0.0.1:5000",
+			"[::1]",
+			"[::1]:5000",
+			"localhost",
+			"localhost:5000",
+			"Example.COM",
+		},
+		Paths: []string{
+			"a",
+			"foo/bar",
+			"foo/bar/baz",
+			"foo-bar",
+			"foo_bar",
+			"foo__bar",
+			"foo.bar",
+			"Foo",
+			strings.Repeat("a", 255),
+		},
+		Tags: []string{
+			"",
+			"latest",
+			"1.0.0",
+			strings.Repeat("a", 128),
+			"-leading-dash",
+		},
+		Digests: []string{
+			"",
+			"sha256:" + strings.Repeat("a", 64),
+			"sha512:" + strings.Repeat("a", 128),
+			"sha1:" + strings.Repeat("a", 40),
+			"sha256:" + strings.Repeat("A", 64),
+			"sha256:" + strings.Repeat("a", 10),
+		},
+	}
+}
+
+// Generate cross-products the configured shapes into candidate
+// reference strings (domain/path, domain/path:tag,
+// domain/path@digest), plus a handful of hand-picked edge cases: an
+// empty reference, a reference with no name, a malformed tag, a non-hex
+// digest, a too-long name, and a domain-less name that only
+// ParseCanonical's normalization-mismatch check rejects. The empty
+// reference and the domain-less name reach ErrNameEmpty and
+// ErrNameNotCanonical, which the cross product doesn't hit; the
+// malformed tag and non-hex digest fail the reference grammar as a
+// whole, so they surface as ErrInvalidFormat.
+func (g *CorpusGenerator) Generate() []string {
+	seeds := []string{
+		"",
+		"@sha256:" + strings.Repeat("a", 64),
+		"example.com/path:-bad-tag!",
+		"example.com/path@sha256:not-hex",
+		strings.Repeat("a", 300) + "/path",
+		"foo/bar",
+	}
+	for _, domain := range g.Domains {
+		for _, path := range g.Paths {
+			base := domain + "/" + path
+			seeds = append(seeds, base)
+			for _, tag := range g.Tags {
+				if tag != "" {
+					seeds = append(seeds, base+":"+tag)
+				}
+			}
+			for _, dig := range g.Digests {
+				if dig != "" {
+					seeds = append(seeds, base+"@"+dig)
+				}
+			}
+		}
+	}
+	return seeds
+}
+
+// WriteCorpus parses every seed with Parse and writes each as a Go
+// native fuzz-corpus file -- the "go test fuzz v1" format `go test
+// -fuzz` itself writes -- under dir, which should be a fuzz target's
+// testdata/fuzz/<FuzzName> directory. It returns the subset of
+// GoErrorClasses that no seed triggered, so callers can tell whether
+// the corpus covers every failure mode the Go reference implementation
+// models -- GoErrorClasses deliberately excludes the Rust-only Ipv6*
+// classes, so this check says nothing about those. ErrNotCanonical is
+// only ever returned by ParseCanonical, so each seed is also probed
+// with that in addition to Parse.
+func WriteCorpus(dir string, seeds []string) (missing []error, err error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	classes := GoErrorClasses()
+	seen := make(map[error]bool, len(classes))
+	for _, seed := range seeds {
+		_, parseErr := Parse(seed)
+		_, canonicalErr := ParseCanonical(seed)
+		for _, class := range classes {
+			if errors.Is(parseErr, class) || errors.Is(canonicalErr, class) {
+				seen[class] = true
+			}
+		}
+		if err := writeCorpusFile(dir, seed); err != nil {
+			return nil, err
+		}
+	}
+	for _, class := range classes {
+		if !seen[class] {
+			missing = append(missing, class)
+		}
+	}
+	return missing, nil
+}
+
+// writeCorpusFile writes seed as a single go-test-fuzz-v1 corpus entry,
+// named after its content hash so re-running WriteCorpus is idempotent.
+func writeCorpusFile(dir, seed string) error {
+	sum := sha256.Sum256([]byte(seed))
+	name := hex.EncodeToString(sum[:])
+	content := fmt.Sprintf("go test fuzz v1\nstring(%q)\n", seed)
+	return os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+}
@@ -0,0 +1,60 @@
+package reference_oracle
+
+import (
+	"os"
+	"testing"
+)
+
+func TestWriteCorpusCoversEveryGoErrorClass(t *testing.T) {
+	dir := t.TempDir()
+	seeds := NewCorpusGenerator().Generate()
+	missing, err := WriteCorpus(dir, seeds)
+	if err != nil {
+		t.Fatalf("WriteCorpus: %v", err)
+	}
+	if len(missing) != 0 {
+		t.Errorf("WriteCorpus left these error classes unexercised: %v", missing)
+	}
+}
+
+func TestWriteCorpusWritesOneFilePerSeed(t *testing.T) {
+	dir := t.TempDir()
+	seeds := []string{"example.com/foo", "example.com/foo:latest"}
+	if _, err := WriteCorpus(dir, seeds); err != nil {
+		t.Fatalf("WriteCorpus: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != len(seeds) {
+		t.Errorf("WriteCorpus wrote %d files, want %d", len(entries), len(seeds))
+	}
+	for _, entry := range entries {
+		data, err := os.ReadFile(dir + "/" + entry.Name())
+		if err != nil {
+			t.Fatalf("ReadFile(%s): %v", entry.Name(), err)
+		}
+		if got := string(data[:len("go test fuzz v1")]); got != "go test fuzz v1" {
+			t.Errorf("corpus file %s has unexpected header %q", entry.Name(), got)
+		}
+	}
+}
+
+func TestWriteCorpusIsIdempotent(t *testing.T) {
+	dir := t.TempDir()
+	seeds := []string{"example.com/foo", "example.com/foo:latest"}
+	if _, err := WriteCorpus(dir, seeds); err != nil {
+		t.Fatalf("first WriteCorpus: %v", err)
+	}
+	if _, err := WriteCorpus(dir, seeds); err != nil {
+		t.Fatalf("second WriteCorpus: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != len(seeds) {
+		t.Errorf("re-running WriteCorpus produced %d files, want %d (should overwrite, not duplicate)", len(entries), len(seeds))
+	}
+}
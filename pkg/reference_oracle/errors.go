@@ -0,0 +1,139 @@
+package reference_oracle
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+)
+
+// Typed errors this package can return from Parse/ParseCanonical, or
+// classify an oracle's reported error name as (see ClassifyError).
+// Callers can compare against these with errors.Is instead of matching
+// on error strings.
+var (
+	ErrInvalidFormat         = errors.New("reference_oracle: invalid reference format")
+	ErrInvalidTag            = errors.New("reference_oracle: invalid tag format")
+	ErrInvalidDigest         = errors.New("reference_oracle: invalid digest format")
+	ErrNameContainsUppercase = errors.New("reference_oracle: name contains uppercase")
+	ErrNameEmpty             = errors.New("reference_oracle: name is empty")
+	ErrNameTooLong           = errors.New("reference_oracle: name is too long")
+	ErrNameNotCanonical      = errors.New("reference_oracle: name is not canonical")
+	ErrNotCanonical          = errors.New("reference_oracle: reference is not canonical")
+	ErrDigestUnsupported     = errors.New("reference_oracle: digest algorithm is unsupported")
+
+	// ErrIPv6TooLong, and the other Ipv6* errors below, classify
+	// failures reported by the Rust oracle's IPv6 host-literal parser,
+	// which is stricter than github.com/distribution/reference: it's
+	// the only failure mode the two parsers are expected to disagree
+	// on.
+	ErrIPv6TooLong          = errors.New("reference_oracle: IPv6 host literal is too long")
+	ErrIPv6BadColon         = errors.New("reference_oracle: IPv6 host literal has a misplaced colon")
+	ErrIPv6TooManyHexDigits = errors.New("reference_oracle: IPv6 host literal group has too many hex digits")
+	ErrIPv6TooManyGroups    = errors.New("reference_oracle: IPv6 host literal has too many groups")
+	ErrIPv6TooFewGroups     = errors.New("reference_oracle: IPv6 host literal has too few groups")
+
+	// ErrUnclassified wraps an oracle-reported error name this package
+	// doesn't recognize; see ClassifyError.
+	ErrUnclassified = errors.New("reference_oracle: unclassified oracle error")
+
+	// ErrUnclassifiedLib wraps an error from github.com/distribution/reference
+	// or github.com/opencontainers/go-digest that wrapLibErr doesn't
+	// recognize, e.g. one of reference.ParseNamed's ad-hoc
+	// regexp-validation errors. Parse/ParseCanonical are public APIs
+	// fed fuzzer-generated input, so an error neither package models
+	// yet is reported this way rather than causing a panic.
+	ErrUnclassifiedLib = errors.New("reference_oracle: unclassified library error")
+)
+
+// wrapLibErr maps an error from github.com/distribution/reference or
+// github.com/opencontainers/go-digest onto this package's typed error
+// hierarchy, wrapping the original with %w so errors.Is/As still reach
+// it.
+func wrapLibErr(err error) error {
+	switch err {
+	case reference.ErrReferenceInvalidFormat:
+		return fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+	case reference.ErrTagInvalidFormat:
+		return fmt.Errorf("%w: %w", ErrInvalidTag, err)
+	case reference.ErrDigestInvalidFormat:
+		return fmt.Errorf("%w: %w", ErrInvalidDigest, err)
+	case reference.ErrNameContainsUppercase:
+		return fmt.Errorf("%w: %w", ErrNameContainsUppercase, err)
+	case reference.ErrNameEmpty:
+		return fmt.Errorf("%w: %w", ErrNameEmpty, err)
+	case reference.ErrNameTooLong:
+		return fmt.Errorf("%w: %w", ErrNameTooLong, err)
+	case reference.ErrNameNotCanonical:
+		return fmt.Errorf("%w: %w", ErrNameNotCanonical, err)
+	case digest.ErrDigestInvalidFormat:
+		return fmt.Errorf("%w: %w", ErrInvalidDigest, err)
+	case digest.ErrDigestInvalidLength:
+		return fmt.Errorf("%w: %w", ErrInvalidDigest, err)
+	case digest.ErrDigestUnsupported:
+		return fmt.Errorf("%w: %w", ErrDigestUnsupported, err)
+	default:
+		return fmt.Errorf("%w: %w", ErrUnclassifiedLib, err)
+	}
+}
+
+// ipv6Classes maps the Rust oracle's IPv6 error-variant name prefixes to
+// this package's typed errors.
+var ipv6Classes = map[string]error{
+	"Ipv6TooLong":          ErrIPv6TooLong,
+	"Ipv6BadColon":         ErrIPv6BadColon,
+	"Ipv6TooManyHexDigits": ErrIPv6TooManyHexDigits,
+	"Ipv6TooManyGroups":    ErrIPv6TooManyGroups,
+	"Ipv6TooFewGroups":     ErrIPv6TooFewGroups,
+}
+
+// ClassifyError maps name, an error name reported by the Rust oracle
+// (e.g. the "Ipv6TooLong" in "Ipv6TooLong: address has 9 groups"), onto
+// this package's typed error hierarchy. Unrecognized names are wrapped
+// in ErrUnclassified rather than discarded, so callers can still log or
+// compare them, and errors.Is(err, ErrUnclassified) reports the oracle
+// surfaced something this package doesn't yet model.
+func ClassifyError(name string) error {
+	for prefix, typed := range ipv6Classes {
+		if strings.HasPrefix(name, prefix) {
+			return typed
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUnclassified, name)
+}
+
+// IsIPv6Error reports whether err classifies as one of the IPv6
+// host-literal errors the Rust oracle is stricter about than
+// github.com/distribution/reference.
+func IsIPv6Error(err error) bool {
+	for _, typed := range ipv6Classes {
+		if errors.Is(err, typed) {
+			return true
+		}
+	}
+	return false
+}
+
+// GoErrorClasses returns every named typed error Parse and
+// ParseCanonical can return; the ErrUnclassifiedLib catch-all is not
+// included. Seed-corpus generators use it to check they've exercised
+// every failure mode the Go reference implementation models; it
+// excludes the Ipv6* classes, which only the Rust oracle produces, and
+// ErrInvalidTag: github.com/distribution/reference only ever raises
+// ErrTagInvalidFormat from WithTag, which Parse/ParseNamed never call
+// with caller-supplied input, so it's unreachable from this package's
+// Parse/ParseCanonical.
+func GoErrorClasses() []error {
+	return []error{
+		ErrInvalidFormat,
+		ErrInvalidDigest,
+		ErrNameContainsUppercase,
+		ErrNameEmpty,
+		ErrNameTooLong,
+		ErrNameNotCanonical,
+		ErrNotCanonical,
+		ErrDigestUnsupported,
+	}
+}
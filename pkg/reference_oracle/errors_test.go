@@ -0,0 +1,73 @@
+package reference_oracle
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestClassifyErrorIPv6Classes(t *testing.T) {
+	cases := map[string]error{
+		"Ipv6TooLong: address has 9 groups":         ErrIPv6TooLong,
+		"Ipv6BadColon: misplaced colon":             ErrIPv6BadColon,
+		"Ipv6TooManyHexDigits: too many hex digits": ErrIPv6TooManyHexDigits,
+		"Ipv6TooManyGroups: too many groups":        ErrIPv6TooManyGroups,
+		"Ipv6TooFewGroups: too few groups":          ErrIPv6TooFewGroups,
+	}
+	for name, want := range cases {
+		if got := ClassifyError(name); !errors.Is(got, want) {
+			t.Errorf("ClassifyError(%q) = %v, want errors.Is(_, %v)", name, got, want)
+		}
+		if !IsIPv6Error(ClassifyError(name)) {
+			t.Errorf("IsIPv6Error(ClassifyError(%q)) = false, want true", name)
+		}
+	}
+}
+
+func TestClassifyErrorUnrecognized(t *testing.T) {
+	err := ClassifyError("SomeNewRustVariant: unexpected thing")
+	if !errors.Is(err, ErrUnclassified) {
+		t.Errorf("ClassifyError of an unrecognized name = %v, want errors.Is(_, ErrUnclassified)", err)
+	}
+	if IsIPv6Error(err) {
+		t.Error("IsIPv6Error(unclassified error) = true, want false")
+	}
+}
+
+func TestParseCanonicalDoesNotPanicOnAdHocLibErrors(t *testing.T) {
+	// A bare 64-hex-char string is the shape ParseNormalizedNamed
+	// rejects with an ad-hoc fmt.Errorf, not one of the named
+	// sentinel errors wrapLibErr switches on.
+	_, err := ParseCanonical(strings.Repeat("a", 64))
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+	if !errors.Is(err, ErrUnclassifiedLib) {
+		t.Errorf("ParseCanonical(64-hex-chars) = %v, want errors.Is(_, ErrUnclassifiedLib)", err)
+	}
+}
+
+func TestParseCanonicalNameNotCanonical(t *testing.T) {
+	_, err := ParseCanonical("foo/bar")
+	if !errors.Is(err, ErrNameNotCanonical) {
+		t.Errorf("ParseCanonical(domain-less name) = %v, want errors.Is(_, ErrNameNotCanonical)", err)
+	}
+}
+
+func TestParseNameContainsUppercase(t *testing.T) {
+	_, err := Parse("example.com/Foo")
+	if !errors.Is(err, ErrNameContainsUppercase) {
+		t.Errorf("Parse(uppercase path) = %v, want errors.Is(_, ErrNameContainsUppercase)", err)
+	}
+}
+
+func TestGoErrorClassesExcludesUnreachableAndIPv6Classes(t *testing.T) {
+	classes := GoErrorClasses()
+	for _, unreachable := range []error{ErrInvalidTag, ErrIPv6TooLong, ErrIPv6BadColon, ErrIPv6TooManyHexDigits, ErrIPv6TooManyGroups, ErrIPv6TooFewGroups} {
+		for _, class := range classes {
+			if class == unreachable {
+				t.Errorf("GoErrorClasses() includes %v, which Parse/ParseCanonical can never produce", unreachable)
+			}
+		}
+	}
+}
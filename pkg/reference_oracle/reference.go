@@ -0,0 +1,93 @@
+// Package reference_oracle wraps github.com/distribution/reference, the
+// Go reference implementation this project's Rust parser is fuzzed
+// against, behind a typed API: a Reference type instead of ad-hoc
+// string fields, and a typed error hierarchy instead of string-matched
+// error messages. A Reference is serialized through the Row that wraps
+// it, as TSV, for the existing fuzz-fixture pipeline, or as JSON, for
+// other consumers; Reference itself has no marshal methods.
+package reference_oracle
+
+import (
+	// these are needed, else `digest.Parse()` will throw errors
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+
+	"github.com/distribution/reference"
+)
+
+// Reference is the structured result of successfully parsing a
+// reference string with github.com/distribution/reference.
+type Reference struct {
+	name          string
+	domain        string
+	path          string
+	tag           string
+	digestAlgo    string
+	digestEncoded string
+}
+
+func (r Reference) Name() string   { return r.name }
+func (r Reference) Domain() string { return r.domain }
+func (r Reference) Path() string   { return r.path }
+func (r Reference) Tag() string    { return r.tag }
+
+// Digest returns the reference's digest algorithm and hex-encoded
+// value, and reports whether the reference carried a digest at all.
+func (r Reference) Digest() (algo, encoded string, ok bool) {
+	return r.digestAlgo, r.digestEncoded, r.digestAlgo != ""
+}
+
+// Canonical reports whether the reference is fully qualified: a name
+// plus a digest, as required by reference.Canonical.
+func (r Reference) Canonical() bool {
+	return r.name != "" && r.digestAlgo != ""
+}
+
+// Parse parses ref with the Go reference implementation, returning a
+// Reference or a typed error from this package's error hierarchy.
+func Parse(ref string) (Reference, error) {
+	parsed, err := reference.Parse(ref)
+	if err != nil {
+		return Reference{}, wrapLibErr(err)
+	}
+	var result Reference
+	if named, ok := parsed.(reference.Named); ok {
+		result.name = named.Name()
+		result.domain = reference.Domain(named)
+		result.path = reference.Path(named)
+	}
+	if tagged, ok := parsed.(reference.Tagged); ok {
+		result.tag = tagged.Tag()
+	}
+	if digested, ok := parsed.(reference.Digested); ok {
+		d := digested.Digest()
+		result.digestAlgo = d.Algorithm().String()
+		result.digestEncoded = d.Encoded()
+	}
+	return result, nil
+}
+
+// ParseCanonical parses ref and requires it to name a fully-qualified
+// reference: a name plus a digest.
+func ParseCanonical(ref string) (Reference, error) {
+	parsed, err := reference.ParseNamed(ref)
+	if err != nil {
+		return Reference{}, wrapLibErr(err)
+	}
+	canonical, ok := parsed.(reference.Canonical)
+	if !ok {
+		return Reference{}, ErrNotCanonical
+	}
+	result := Reference{
+		name:   canonical.Name(),
+		domain: reference.Domain(canonical),
+		path:   reference.Path(canonical),
+	}
+	if tagged, ok := canonical.(reference.Tagged); ok {
+		result.tag = tagged.Tag()
+	}
+	d := canonical.Digest()
+	result.digestAlgo = d.Algorithm().String()
+	result.digestEncoded = d.Encoded()
+	return result, nil
+}
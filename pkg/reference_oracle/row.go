@@ -0,0 +1,183 @@
+package reference_oracle
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Row is the wire format a Reference (or the error in its place) is
+// exchanged in: one TSV line, or the equivalent JSON object. It's what
+// lets the TSV fixture pipeline and JSON consumers share a single parse
+// output instead of each having their own ad-hoc encoding.
+type Row struct {
+	Input string
+	Reference
+	// Err is the oracle-reported error name/message for inputs that
+	// failed to parse; empty on success. Use ClassifyError(row.Err) to
+	// get a typed error.
+	Err string
+}
+
+// FromReference builds a Row recording a successful parse of input.
+func FromReference(input string, ref Reference) Row {
+	return Row{Input: input, Reference: ref}
+}
+
+// FromError builds a Row recording a failed parse of input. err is
+// recorded as its message (err.Error()) only, so the typed error itself
+// is not kept: ClassifyError recognizes only the Rust oracle's Ipv6*
+// error names and reports any other message as ErrUnclassified.
+func FromError(input string, err error) Row {
+	return Row{Input: input, Err: err.Error()}
+}
+
+// ToReference reports the Reference a successful Row parsed to, or the
+// typed error a failed Row's Err classifies as.
+func (row Row) ToReference() (Reference, error) {
+	if row.Err != "" {
+		return Reference{}, ClassifyError(row.Err)
+	}
+	return row.Reference, nil
+}
+
+// EscapeField encodes embedded tabs/newlines/carriage-returns/backslashes
+// so a field survives the TSV and line-delimited subprocess protocols.
+// UnescapeField undoes this.
+func EscapeField(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "\t", "\\t")
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	s = strings.ReplaceAll(s, "\r", "\\r")
+	return s
+}
+
+// UnescapeField decodes a field encoded by EscapeField. It scans s
+// left-to-right and consumes one escape token at a time, since the
+// reverse of EscapeField's sequential ReplaceAll passes is not
+// invertible: e.g. a literal "\\nb" (backslash + "nb") escapes to
+// "\\\\nb", and undoing that with global passes turns it into "\nb" (a
+// real newline + "b") or "\\\nb" (backslash + a real newline + "b")
+// depending on pass order, never back into the original.
+func UnescapeField(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 >= len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		switch s[i+1] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case '\\':
+			b.WriteByte('\\')
+		default:
+			b.WriteByte(c)
+			continue
+		}
+		i++
+	}
+	return b.String()
+}
+
+// MarshalTSV encodes row as the tab-separated line this project's test
+// fixtures and fuzz harnesses exchange with the Rust oracle:
+// input, name, domain, path, tag, digest_algo, digest_encoded, err.
+func (row Row) MarshalTSV() string {
+	algo, encoded, _ := row.Digest()
+	fields := []string{
+		row.Input,
+		row.Name(),
+		row.Domain(),
+		row.Path(),
+		row.Tag(),
+		algo,
+		encoded,
+		row.Err,
+	}
+	for i, field := range fields {
+		fields[i] = EscapeField(field)
+	}
+	return strings.Join(fields, "\t") + "\n"
+}
+
+// UnmarshalTSV decodes a line written by MarshalTSV (or by the Rust
+// oracle's TSV writer). It returns an error instead of panicking when
+// line doesn't have the expected 8 fields, so callers parsing untrusted
+// subprocess output can report a clean failure.
+func UnmarshalTSV(line string) (Row, error) {
+	fields := strings.Split(strings.TrimRight(line, "\n"), "\t")
+	if len(fields) != 8 {
+		return Row{}, fmt.Errorf("reference_oracle: expected 8 TSV fields, got %d: %q", len(fields), line)
+	}
+	for i, field := range fields {
+		fields[i] = UnescapeField(field)
+	}
+	return Row{
+		Input: fields[0],
+		Reference: Reference{
+			name:          fields[1],
+			domain:        fields[2],
+			path:          fields[3],
+			tag:           fields[4],
+			digestAlgo:    fields[5],
+			digestEncoded: fields[6],
+		},
+		Err: fields[7],
+	}, nil
+}
+
+// jsonRow mirrors Row's TSV column names for JSON consumers (CI,
+// other-language bindings) that shouldn't have to know this package's
+// unexported Reference fields.
+type jsonRow struct {
+	Input         string `json:"input"`
+	Name          string `json:"name"`
+	Domain        string `json:"domain"`
+	Path          string `json:"path"`
+	Tag           string `json:"tag"`
+	DigestAlgo    string `json:"digest_algo"`
+	DigestEncoded string `json:"digest_encoded"`
+	Err           string `json:"err"`
+}
+
+// MarshalJSON implements json.Marshaler, using the same field names as
+// the TSV header so JSON and TSV consumers agree on shape.
+func (row Row) MarshalJSON() ([]byte, error) {
+	algo, encoded, _ := row.Digest()
+	return json.Marshal(jsonRow{
+		Input:         row.Input,
+		Name:          row.Name(),
+		Domain:        row.Domain(),
+		Path:          row.Path(),
+		Tag:           row.Tag(),
+		DigestAlgo:    algo,
+		DigestEncoded: encoded,
+		Err:           row.Err,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, the inverse of MarshalJSON.
+func (row *Row) UnmarshalJSON(data []byte) error {
+	var parsed jsonRow
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return err
+	}
+	row.Input = parsed.Input
+	row.Reference = Reference{
+		name:          parsed.Name,
+		domain:        parsed.Domain,
+		path:          parsed.Path,
+		tag:           parsed.Tag,
+		digestAlgo:    parsed.DigestAlgo,
+		digestEncoded: parsed.DigestEncoded,
+	}
+	row.Err = parsed.Err
+	return nil
+}
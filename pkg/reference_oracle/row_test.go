@@ -0,0 +1,82 @@
+package reference_oracle
+
+import "testing"
+
+func TestEscapeFieldRoundTrip(t *testing.T) {
+	cases := []string{
+		"",
+		"plain",
+		"a\\nb", // literal backslash followed by "nb", not a real newline
+		"a\tb\nc\rd\\e",
+		"\\\\\\\\",
+		"trailing\\",
+	}
+	for _, in := range cases {
+		escaped := EscapeField(in)
+		out := UnescapeField(escaped)
+		if out != in {
+			t.Errorf("UnescapeField(EscapeField(%q)) = %q, want %q (escaped: %q)", in, out, in, escaped)
+		}
+	}
+}
+
+func TestUnescapeFieldDecodesEscapes(t *testing.T) {
+	cases := map[string]string{
+		`a\tb`: "a\tb",
+		`a\nb`: "a\nb",
+		`a\rb`: "a\rb",
+		`a\\b`: `a\b`,
+		`a\qb`: `a\qb`, // unrecognized escape passes through unchanged
+	}
+	for escaped, want := range cases {
+		if got := UnescapeField(escaped); got != want {
+			t.Errorf("UnescapeField(%q) = %q, want %q", escaped, got, want)
+		}
+	}
+}
+
+func TestRowTSVRoundTrip(t *testing.T) {
+	cases := []Row{
+		FromReference("example.com/foo:latest", Reference{
+			name: "example.com/foo", domain: "example.com", path: "foo", tag: "latest",
+		}),
+		FromReference("example.com/foo@sha256:abc", Reference{
+			name: "example.com/foo", domain: "example.com", path: "foo",
+			digestAlgo: "sha256", digestEncoded: "abc",
+		}),
+		{Input: "a\tb\nc\\d", Err: "some\nerror\twith\\escapes"},
+	}
+	for _, row := range cases {
+		line := row.MarshalTSV()
+		decoded, err := UnmarshalTSV(line)
+		if err != nil {
+			t.Fatalf("UnmarshalTSV(%q): %v", line, err)
+		}
+		if decoded != row {
+			t.Errorf("round-trip mismatch:\n  in:  %+v\n  out: %+v\n  tsv: %q", row, decoded, line)
+		}
+	}
+}
+
+func TestUnmarshalTSVRejectsWrongFieldCount(t *testing.T) {
+	if _, err := UnmarshalTSV("too\tfew\tfields\n"); err == nil {
+		t.Error("expected an error for a line with fewer than 8 fields")
+	}
+}
+
+func TestRowJSONRoundTrip(t *testing.T) {
+	row := FromReference("example.com/foo:latest", Reference{
+		name: "example.com/foo", domain: "example.com", path: "foo", tag: "latest",
+	})
+	data, err := row.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+	var decoded Row
+	if err := decoded.UnmarshalJSON(data); err != nil {
+		t.Fatalf("UnmarshalJSON: %v", err)
+	}
+	if decoded != row {
+		t.Errorf("JSON round-trip mismatch:\n  in:  %+v\n  out: %+v\n  json: %s", row, decoded, data)
+	}
+}
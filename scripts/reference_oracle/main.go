@@ -6,12 +6,7 @@ import (
 	"os"
 	"strings"
 
-	// these are needed, else `digest.Parse()` will throw errors
-	_ "crypto/sha256"
-	_ "crypto/sha512"
-
-	"github.com/distribution/reference"
-	"github.com/opencontainers/go-digest"
+	oracle "github.com/skalt/container_image_dist_ref/pkg/reference_oracle"
 )
 
 func panicIf(err error) {
@@ -24,108 +19,52 @@ func mustWrite(writer io.StringWriter, s string) {
 	panicIf(err)
 }
 
-type parseResult struct {
-	input, name, domain, path, tag, digestAlgo, digestEncoded, err string
-}
-
-func (result parseResult) row() string {
-	return strings.Join([]string{
-		result.input,
-		result.name,
-		result.domain,
-		result.path,
-		result.tag,
-		result.digestAlgo,
-		result.digestEncoded,
-		result.err,
-	}, "\t") + "\n"
-}
-func parse(ref string) (result parseResult) {
-	result.input = ref
-	parsed, err := reference.Parse(ref)
+func parse(ref string) oracle.Row {
+	parsed, err := oracle.Parse(ref)
 	if err != nil {
-		result.err = err.Error()
-		switch err {
-		case reference.ErrReferenceInvalidFormat:
-		case reference.ErrTagInvalidFormat:
-		case reference.ErrDigestInvalidFormat:
-		case reference.ErrNameContainsUppercase:
-		case reference.ErrNameEmpty:
-		case reference.ErrNameTooLong:
-		case reference.ErrNameNotCanonical:
-
-		case digest.ErrDigestInvalidFormat:
-		case digest.ErrDigestInvalidLength:
-		case digest.ErrDigestUnsupported:
-			break
-		default:
-			panic(fmt.Sprintf("unexpected error: %v", err))
-		}
-		return
-	} else {
-		if named, ok := parsed.(reference.Named); ok {
-			result.name = named.Name()
-			result.domain = reference.Domain(named)
-			result.path = reference.Path(named)
-		}
-		if tagged, ok := parsed.(reference.Tagged); ok {
-			result.tag = tagged.Tag()
-		}
-		if digested, ok := parsed.(reference.Digested); ok {
-			digest := digested.Digest()
-			result.digestAlgo = digest.Algorithm().String()
-			result.digestEncoded = digest.Encoded()
-		}
-		return
+		return oracle.FromError(ref, err)
 	}
+	return oracle.FromReference(ref, parsed)
 }
 
 func parseValid(ref string, accumulator *strings.Builder) {
-	result, err := reference.Parse(ref)
+	result, err := oracle.Parse(ref)
 	if err != nil {
 		panic(fmt.Sprintf("expected success, but produced %v for %s", err, ref))
 	}
-	if true { // <- useless block so I can visually align the output code
-		mustWrite(accumulator, fmt.Sprintf("- input:          \"%s\"\n", ref))
-		mustWrite(accumulator, fmt.Sprintf("  result:         \"%s\"\n", result.String()))
-	}
-	if named, ok := result.(reference.Named); ok {
-		mustWrite(accumulator, fmt.Sprintf("  name:           \"%s\"\n", named.Name()))
-		domain := reference.Domain(named)
-		mustWrite(accumulator, fmt.Sprintf("  domain:         \"%s\"\n", domain))
-		path := reference.Path(named)
-		mustWrite(accumulator, fmt.Sprintf("  path:           \"%s\"\n", path))
-	}
-	if tagged, ok := result.(reference.Tagged); ok {
-		mustWrite(accumulator, fmt.Sprintf("  tag:            \"%s\"\n", tagged.Tag()))
+	mustWrite(accumulator, fmt.Sprintf("- input:          \"%s\"\n", ref))
+	mustWrite(accumulator, fmt.Sprintf("  name:           \"%s\"\n", result.Name()))
+	mustWrite(accumulator, fmt.Sprintf("  domain:         \"%s\"\n", result.Domain()))
+	mustWrite(accumulator, fmt.Sprintf("  path:           \"%s\"\n", result.Path()))
+	if tag := result.Tag(); tag != "" {
+		mustWrite(accumulator, fmt.Sprintf("  tag:            \"%s\"\n", tag))
 	} else {
-		mustWrite(accumulator, fmt.Sprintf("  tag:            null\n"))
+		mustWrite(accumulator, "  tag:            null\n")
 	}
-	if digested, ok := result.(reference.Digested); ok {
-		digest := digested.Digest()
-		algorithm := digest.Algorithm().String()
-		mustWrite(accumulator, fmt.Sprintf("  digest_algo:    \"%s\"\n", algorithm))
-		mustWrite(accumulator, fmt.Sprintf("  digest_encoded: \"%s\"\n", digest.Encoded()))
+	if algo, encoded, ok := result.Digest(); ok {
+		mustWrite(accumulator, fmt.Sprintf("  digest_algo:    \"%s\"\n", algo))
+		mustWrite(accumulator, fmt.Sprintf("  digest_encoded: \"%s\"\n", encoded))
 	} else {
-		mustWrite(accumulator, fmt.Sprintf("  digest_algo:    null\n"))
-		mustWrite(accumulator, fmt.Sprintf("  digest_encoded: null\n"))
+		mustWrite(accumulator, "  digest_algo:    null\n")
+		mustWrite(accumulator, "  digest_encoded: null\n")
 	}
 }
 
 func parseInvalid(ref string, accumulator *strings.Builder) {
-	result, err := reference.Parse(ref)
+	_, err := oracle.Parse(ref)
 	if err == nil {
-		panic(fmt.Sprintf("expected error, but produced %v for %s", result, ref))
+		panic(fmt.Sprintf("expected error, but %s parsed successfully", ref))
 	}
 	mustWrite(accumulator, fmt.Sprintf("- input: \"%s\"\n", ref))
 	mustWrite(accumulator, fmt.Sprintf("  err:   \"%v\"\n", err))
 }
+
 func parseFileLines(inputs string, output io.StringWriter) {
-	for _, line := range strings.Split(string(inputs), "\n") {
+	for _, line := range strings.Split(inputs, "\n") {
 		if line == "" {
 			continue
 		}
-		mustWrite(output, parse(line).row())
+		mustWrite(output, parse(line).MarshalTSV())
 	}
 }
 